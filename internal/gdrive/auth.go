@@ -0,0 +1,121 @@
+package gdrive
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// newClient builds an authenticated HTTP client for the given config,
+// dispatching to a service account or interactive user flow depending on
+// cfg.CredentialType.
+func newClient(cfg *Config) (*http.Client, error) {
+	b, err := ioutil.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials file: %v", err)
+	}
+
+	switch cfg.CredentialType {
+	case CredentialTypeServiceAccount:
+		jwtConfig, err := google.JWTConfigFromJSON(b, cfg.scopes()...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse service account key: %v", err)
+		}
+		return jwtConfig.Client(context.Background()), nil
+	case CredentialTypeUserOAuth:
+		oauthConfig, err := google.ConfigFromJSON(b, cfg.scopes()...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse client secret file: %v", err)
+		}
+		return getClient(cfg, oauthConfig)
+	default:
+		return nil, fmt.Errorf("unknown credential type %v", cfg.CredentialType)
+	}
+}
+
+// Retrieve a token, saves the token, then returns the generated client.
+func getClient(cfg *Config, config *oauth2.Config) (*http.Client, error) {
+	tokFile := cfg.tokenCacheFile()
+	tok, err := tokenFromFile(tokFile)
+	if err != nil {
+		tok, err = getTokenFromLoopback(cfg, config)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get OAuth token: %v", err)
+		}
+		if err := saveToken(tokFile, tok); err != nil {
+			return nil, fmt.Errorf("unable to cache OAuth token: %v", err)
+		}
+	}
+	return config.Client(context.Background(), tok), nil
+}
+
+// getTokenFromLoopback drives the OAuth2 authorization code flow by
+// spinning up a local HTTP server to receive the redirect, instead of
+// asking the user to paste a code on stdin.
+func getTokenFromLoopback(cfg *Config, config *oauth2.Config) (*oauth2.Token, error) {
+	state, err := randomState()
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate OAuth state: %v", err)
+	}
+
+	redirectURL := fmt.Sprintf("http://127.0.0.1:%d/", cfg.oauthRedirectPort())
+	config.RedirectURL = redirectURL
+
+	codeCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("state"); got != state {
+			errCh <- fmt.Errorf("state mismatch: got %q", got)
+			http.Error(w, "state mismatch", http.StatusBadRequest)
+			return
+		}
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			errCh <- fmt.Errorf("no code in callback")
+			http.Error(w, "missing code", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "Authentication complete, you may close this tab.")
+		codeCh <- code
+	})
+
+	server := &http.Server{Addr: fmt.Sprintf("127.0.0.1:%d", cfg.oauthRedirectPort()), Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+	defer server.Close()
+
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	fmt.Printf("Go to the following link in your browser to authorize access:\n%v\n", authURL)
+
+	var authCode string
+	select {
+	case authCode = <-codeCh:
+	case err := <-errCh:
+		return nil, fmt.Errorf("OAuth callback failed: %v", err)
+	}
+
+	tok, err := config.Exchange(context.TODO(), authCode)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve token from web: %v", err)
+	}
+	return tok, nil
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,121 @@
+package gdrive
+
+import "time"
+
+// CredentialType selects how GDrive authenticates against the Google APIs.
+type CredentialType int
+
+const (
+	// CredentialTypeUserOAuth drives the interactive OAuth2 authorization
+	// code flow for a regular Google account, completed via a local
+	// loopback redirect instead of copy-pasting a code on stdin.
+	CredentialTypeUserOAuth CredentialType = iota
+	// CredentialTypeServiceAccount authenticates using a service-account
+	// JSON key, which requires no user interaction and is suitable for
+	// running inside a Kubernetes pod.
+	CredentialTypeServiceAccount
+)
+
+// Config controls how Create connects to Google Drive and Sheets. The
+// zero value is not usable; populate CredentialType and CredentialsFile
+// at a minimum.
+type Config struct {
+	// CredentialType selects between a service account and interactive
+	// user OAuth.
+	CredentialType CredentialType
+
+	// CredentialsFile is the path to the credentials JSON. For
+	// CredentialTypeServiceAccount this is a service-account key file;
+	// for CredentialTypeUserOAuth this is an OAuth client secret file.
+	CredentialsFile string
+
+	// TokenCacheFile is where the user OAuth token is cached between
+	// runs. Ignored for service accounts. Defaults to "token.json".
+	TokenCacheFile string
+
+	// Scopes are the OAuth scopes requested. Defaults to drive.file and
+	// spreadsheets if left empty.
+	Scopes []string
+
+	// FolderName is the top-level folder LogRow creates sheets under.
+	// Defaults to "k8sheets". Ignored if FolderPath is set.
+	FolderName string
+
+	// FolderPath, when set, is a Unix-style path of folder names (e.g.
+	// "/team/k8s/metrics") resolved and created on demand via
+	// ResolvePath, letting sheets live in a nested folder instead of at
+	// the Drive/Shared-Drive root. Takes precedence over FolderName.
+	FolderPath string
+
+	// OAuthRedirectPort is the localhost port the loopback redirect
+	// handler listens on during the user OAuth flow. Defaults to 8085.
+	OAuthRedirectPort int
+
+	// DriveId, when set, targets a Shared Drive instead of the user's
+	// "My Drive". All Files.List/Files.Create calls are made with
+	// SupportsAllDrives and, for Files.List, IncludeItemsFromAllDrives.
+	DriveId string
+
+	// FolderCacheSize bounds the number of (parentID, name) -> folderID
+	// entries ResolvePath keeps in memory. Defaults to 256.
+	FolderCacheSize int
+
+	// FolderCacheTTL is how long a resolved folder ID stays valid before
+	// ResolvePath looks it up again. Defaults to 5 minutes.
+	FolderCacheTTL time.Duration
+}
+
+func (c *Config) scopes() []string {
+	if len(c.Scopes) > 0 {
+		return c.Scopes
+	}
+	return []string{
+		"https://www.googleapis.com/auth/drive.file",
+		"https://www.googleapis.com/auth/spreadsheets",
+	}
+}
+
+func (c *Config) tokenCacheFile() string {
+	if c.TokenCacheFile != "" {
+		return c.TokenCacheFile
+	}
+	return "token.json"
+}
+
+func (c *Config) folderName() string {
+	if c.FolderName != "" {
+		return c.FolderName
+	}
+	return "k8sheets"
+}
+
+// folderPath returns the Drive path Create resolves the sheets folder
+// from, preferring FolderPath and falling back to the single-segment
+// FolderName/default.
+func (c *Config) folderPath() string {
+	if c.FolderPath != "" {
+		return c.FolderPath
+	}
+	return c.folderName()
+}
+
+func (c *Config) oauthRedirectPort() int {
+	if c.OAuthRedirectPort != 0 {
+		return c.OAuthRedirectPort
+	}
+	return 8085
+}
+
+func (c *Config) folderCacheSize() int {
+	if c.FolderCacheSize != 0 {
+		return c.FolderCacheSize
+	}
+	return 256
+}
+
+func (c *Config) folderCacheTTL() time.Duration {
+	if c.FolderCacheTTL != 0 {
+		return c.FolderCacheTTL
+	}
+	return 5 * time.Minute
+}
@@ -0,0 +1,95 @@
+package gdrive
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// folderCache is a small LRU cache, bounded by entry count and per-entry
+// TTL, mapping a (parentID, name) folder lookup to the resolved folder
+// ID. ResolvePath uses it so that repeated LogRow calls against the same
+// path don't re-walk every segment through the Drive API.
+type folderCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type folderCacheEntry struct {
+	key      string
+	folderID string
+	expires  time.Time
+}
+
+func newFolderCache(capacity int, ttl time.Duration) *folderCache {
+	return &folderCache{
+		ttl:      ttl,
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func folderCacheKey(parentID, name string) string {
+	return parentID + "/" + name
+}
+
+func (c *folderCache) get(parentID, name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[folderCacheKey(parentID, name)]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*folderCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.removeElement(elem)
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return entry.folderID, true
+}
+
+func (c *folderCache) set(parentID, name, folderID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := folderCacheKey(parentID, name)
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*folderCacheEntry).folderID = folderID
+		elem.Value.(*folderCacheEntry).expires = time.Now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&folderCacheEntry{
+		key:      key,
+		folderID: folderID,
+		expires:  time.Now().Add(c.ttl),
+	})
+	c.items[key] = elem
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// invalidate removes a cached entry, used when the caller knows a
+// folder was created or deleted out from under the cache.
+func (c *folderCache) invalidate(parentID, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[folderCacheKey(parentID, name)]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *folderCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*folderCacheEntry).key)
+}
@@ -0,0 +1,56 @@
+package gdrive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFolderCacheGetSetInvalidate(t *testing.T) {
+	c := newFolderCache(2, time.Hour)
+
+	if _, ok := c.get("root", "team"); ok {
+		t.Fatalf("expected miss on empty cache")
+	}
+
+	c.set("root", "team", "folder-1")
+	if id, ok := c.get("root", "team"); !ok || id != "folder-1" {
+		t.Fatalf("expected hit with folder-1, got %q, %v", id, ok)
+	}
+
+	c.invalidate("root", "team")
+	if _, ok := c.get("root", "team"); ok {
+		t.Fatalf("expected miss after invalidate")
+	}
+}
+
+func TestFolderCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newFolderCache(2, time.Hour)
+
+	c.set("root", "a", "folder-a")
+	c.set("root", "b", "folder-b")
+	// Touch "a" so "b" becomes the least recently used entry.
+	if _, ok := c.get("root", "a"); !ok {
+		t.Fatalf("expected hit for a")
+	}
+
+	c.set("root", "c", "folder-c")
+
+	if _, ok := c.get("root", "b"); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if _, ok := c.get("root", "a"); !ok {
+		t.Fatalf("expected a to still be cached")
+	}
+	if _, ok := c.get("root", "c"); !ok {
+		t.Fatalf("expected c to be cached")
+	}
+}
+
+func TestFolderCacheExpiresEntries(t *testing.T) {
+	c := newFolderCache(10, -time.Minute)
+
+	c.set("root", "team", "folder-1")
+	if _, ok := c.get("root", "team"); ok {
+		t.Fatalf("expected entry with a TTL already in the past to be treated as expired")
+	}
+}
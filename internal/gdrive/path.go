@@ -0,0 +1,86 @@
+package gdrive
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+)
+
+// ResolvePath walks a Unix-style path of folder names under root (or,
+// when GDrive was configured with Config.DriveId, under that Shared
+// Drive), creating any segment that doesn't exist yet, and returns the
+// ID of the final folder. Each hop is cached so repeated calls for the
+// same path don't re-walk it through the Drive API every time.
+func (gDrive *GDrive) ResolvePath(ctx context.Context, path string) (string, error) {
+	parentID := "root"
+	if gDrive.driveID != "" {
+		parentID = gDrive.driveID
+	}
+
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+
+		folderID, err := gDrive.resolveFolder(ctx, parentID, segment)
+		if err != nil {
+			return "", err
+		}
+		parentID = folderID
+	}
+
+	return parentID, nil
+}
+
+func (gDrive *GDrive) resolveFolder(ctx context.Context, parentID, name string) (string, error) {
+	if folderID, ok := gDrive.folderCache.get(parentID, name); ok {
+		return folderID, nil
+	}
+
+	call := gDrive.driveSrv.Files.List().
+		Context(ctx).
+		Q(fmt.Sprintf(
+			"name = '%s' and '%s' in parents and mimeType = 'application/vnd.google-apps.folder' and trashed = false",
+			escapeQueryValue(name), parentID,
+		)).
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true)
+	if gDrive.driveID != "" {
+		call = call.Corpora("drive").DriveId(gDrive.driveID)
+	}
+
+	files, err := call.Do()
+	if err != nil {
+		return "", err
+	}
+
+	var folderID string
+	switch len(files.Files) {
+	case 0:
+		folder, err := gDrive.driveSrv.Files.Create(&drive.File{
+			Name:     name,
+			MimeType: "application/vnd.google-apps.folder",
+			Parents:  []string{parentID},
+		}).
+			Context(ctx).
+			SupportsAllDrives(true).
+			Do()
+		if err != nil {
+			return "", err
+		}
+		folderID = folder.Id
+	case 1:
+		folderID = files.Files[0].Id
+	default:
+		return "", fmt.Errorf("got %d folders named %s under %s, expected 1", len(files.Files), name, parentID)
+	}
+
+	gDrive.folderCache.set(parentID, name, folderID)
+	return folderID, nil
+}
+
+func escapeQueryValue(s string) string {
+	return strings.ReplaceAll(s, "'", "\\'")
+}
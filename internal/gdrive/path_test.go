@@ -0,0 +1,97 @@
+package gdrive
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// newTestGDrive returns a GDrive whose Drive API calls are served by a
+// local httptest server instead of the real Google API.
+func newTestGDrive(t *testing.T, handler http.HandlerFunc) *GDrive {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	driveSrv, err := drive.NewService(context.Background(),
+		option.WithHTTPClient(server.Client()),
+		option.WithEndpoint(server.URL),
+	)
+	if err != nil {
+		t.Fatalf("unable to create test drive service: %v", err)
+	}
+
+	return &GDrive{
+		driveSrv:    driveSrv,
+		folderCache: newFolderCache(10, time.Minute),
+	}
+}
+
+func TestResolveFolderCreatesMissingSegment(t *testing.T) {
+	var listCalls, createCalls int
+	gDrive := newTestGDrive(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			listCalls++
+			json.NewEncoder(w).Encode(&drive.FileList{})
+		case http.MethodPost:
+			createCalls++
+			json.NewEncoder(w).Encode(&drive.File{Id: "new-folder-id"})
+		default:
+			t.Fatalf("unexpected method %s", r.Method)
+		}
+	})
+
+	id, err := gDrive.resolveFolder(context.Background(), "root", "metrics")
+	if err != nil {
+		t.Fatalf("resolveFolder: %v", err)
+	}
+	if id != "new-folder-id" {
+		t.Fatalf("expected new-folder-id, got %q", id)
+	}
+	if listCalls != 1 || createCalls != 1 {
+		t.Fatalf("expected one List and one Create call, got %d/%d", listCalls, createCalls)
+	}
+
+	// A second call for the same (parentID, name) must be served from
+	// the cache rather than hitting the API again.
+	id, err = gDrive.resolveFolder(context.Background(), "root", "metrics")
+	if err != nil {
+		t.Fatalf("resolveFolder (cached): %v", err)
+	}
+	if id != "new-folder-id" {
+		t.Fatalf("expected cached new-folder-id, got %q", id)
+	}
+	if listCalls != 1 || createCalls != 1 {
+		t.Fatalf("expected no additional API calls on cache hit, got %d/%d", listCalls, createCalls)
+	}
+}
+
+func TestResolvePathWalksEverySegment(t *testing.T) {
+	seen := map[string]bool{}
+	gDrive := newTestGDrive(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Fatalf("unexpected method %s", r.Method)
+		}
+		name := r.URL.Query().Get("q")
+		seen[name] = true
+		json.NewEncoder(w).Encode(&drive.FileList{Files: []*drive.File{{Id: "folder-" + name}}})
+	})
+
+	id, err := gDrive.ResolvePath(context.Background(), "/team/k8s/metrics")
+	if err != nil {
+		t.Fatalf("ResolvePath: %v", err)
+	}
+	if id == "" {
+		t.Fatalf("expected a resolved folder id")
+	}
+	if len(seen) != 3 {
+		t.Fatalf("expected a List call per path segment, got %d", len(seen))
+	}
+}
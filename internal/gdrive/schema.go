@@ -0,0 +1,298 @@
+package gdrive
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// schemaSheetName is a hidden sheet inside every spreadsheet LogRow
+// writes to, storing the ordered, stable column layout for the sheet
+// next to it. Without it, column positions would shuffle between runs
+// because `data`'s key order is not deterministic.
+const schemaSheetName = "__schema"
+
+// schemaColumn is one column of a sheet's stored schema.
+type schemaColumn struct {
+	Key        string
+	Index      int
+	Deprecated bool
+}
+
+// sheetSchema is the column layout cached for a single spreadsheet.
+// Existing keys keep their index forever; keys no longer present in
+// `data` are marked Deprecated rather than removed, so previously
+// appended rows stay aligned with their columns.
+type sheetSchema struct {
+	columns []schemaColumn
+	byKey   map[string]int
+
+	// dirty is set when diff changes a column's Deprecated flag, so
+	// syncSchema knows to persist even when no new column was added.
+	dirty bool
+}
+
+func (s *sheetSchema) width() int {
+	return len(s.columns)
+}
+
+func (s *sheetSchema) indexOf(key string) (int, bool) {
+	i, ok := s.byKey[key]
+	return i, ok
+}
+
+// diff marks columns whose key is absent from keys as deprecated (and
+// un-deprecates ones that reappear), and returns the keys that have
+// never been seen before, in a deterministic order.
+func (s *sheetSchema) diff(keys []string) []string {
+	present := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		present[k] = true
+	}
+
+	var newKeys []string
+	for _, k := range keys {
+		if _, ok := s.byKey[k]; !ok {
+			newKeys = append(newKeys, k)
+		}
+	}
+
+	for i := range s.columns {
+		deprecated := !present[s.columns[i].Key]
+		if deprecated != s.columns[i].Deprecated {
+			s.columns[i].Deprecated = deprecated
+			s.dirty = true
+		}
+	}
+
+	return newKeys
+}
+
+// add appends a new column for key at the next free index. Existing
+// indices are never reused or shifted.
+func (s *sheetSchema) add(key string) schemaColumn {
+	col := schemaColumn{Key: key, Index: len(s.columns)}
+	s.columns = append(s.columns, col)
+	s.byKey[key] = col.Index
+	return col
+}
+
+// valuesForSchema lays row out as a single full-width slice per sample,
+// with each key's value placed at its schema column index, so Append
+// can write it as one contiguous row without disturbing other columns.
+func valuesForSchema(schema *sheetSchema, row map[string][]interface{}) [][]interface{} {
+	n := 0
+	for _, samples := range row {
+		if len(samples) > n {
+			n = len(samples)
+		}
+	}
+
+	width := schema.width()
+	rows := make([][]interface{}, n)
+	for i := 0; i < n; i++ {
+		values := make([]interface{}, width)
+		for key, samples := range row {
+			index, ok := schema.indexOf(key)
+			if !ok || i >= len(samples) {
+				continue
+			}
+			values[index] = samples[i]
+		}
+		rows[i] = values
+	}
+	return rows
+}
+
+// schemaFor returns the cached schema for sheet, loading it from the
+// spreadsheet's "__schema" sheet on first use.
+func (gDrive *GDrive) schemaFor(sheet *sheets.Spreadsheet) (*sheetSchema, error) {
+	if schema, ok := gDrive.cachedSchema(sheet.SpreadsheetId); ok {
+		return schema, nil
+	}
+
+	schema, err := gDrive.loadSchema(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	gDrive.mu.Lock()
+	defer gDrive.mu.Unlock()
+	if gDrive.schemas == nil {
+		gDrive.schemas = make(map[string]*sheetSchema)
+	}
+	if existing, ok := gDrive.schemas[sheet.SpreadsheetId]; ok {
+		return existing, nil
+	}
+	gDrive.schemas[sheet.SpreadsheetId] = schema
+	return schema, nil
+}
+
+// cachedSchema returns the schema already loaded for spreadsheetID, if any.
+func (gDrive *GDrive) cachedSchema(spreadsheetID string) (*sheetSchema, bool) {
+	gDrive.mu.Lock()
+	defer gDrive.mu.Unlock()
+	schema, ok := gDrive.schemas[spreadsheetID]
+	return schema, ok
+}
+
+// syncSchema diffs row's keys against the cached schema, grows the data
+// sheet and the stored schema for any new key, and persists any change
+// in deprecation state. The returned bool reports whether the schema
+// actually changed, so callers can skip re-writing the header when it
+// didn't.
+//
+// gDrive.mu is held for the whole diff/add/persist sequence below, not
+// just the schemaFor lookup above: schema is a pointer shared across
+// every caller of LogRow, and diff/add mutate its columns slice and
+// byKey map in place, so two callers racing here can otherwise corrupt
+// or crash on the map.
+func (gDrive *GDrive) syncSchema(sheet *sheets.Spreadsheet, row map[string][]interface{}) (*sheetSchema, bool, error) {
+	schema, err := gDrive.schemaFor(sheet)
+	if err != nil {
+		return nil, false, err
+	}
+
+	gDrive.mu.Lock()
+	defer gDrive.mu.Unlock()
+
+	keys := make([]string, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	newKeys := schema.diff(keys)
+	if len(newKeys) == 0 && !schema.dirty {
+		return schema, false, nil
+	}
+
+	dataSheetID := primarySheetID(sheet)
+	for _, key := range newKeys {
+		schema.add(key)
+		if err := gDrive.appendDataColumn(sheet.SpreadsheetId, dataSheetID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := gDrive.persistSchema(sheet.SpreadsheetId, schema); err != nil {
+		return nil, false, err
+	}
+	schema.dirty = false
+
+	return schema, true, nil
+}
+
+func (gDrive *GDrive) loadSchema(sheet *sheets.Spreadsheet) (*sheetSchema, error) {
+	if err := gDrive.ensureSchemaSheet(sheet); err != nil {
+		return nil, err
+	}
+
+	resp, err := gDrive.sheetsSrv.Spreadsheets.Values.Get(sheet.SpreadsheetId, schemaSheetName+"!A2:C").Do()
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &sheetSchema{byKey: make(map[string]int)}
+	for _, row := range resp.Values {
+		if len(row) == 0 {
+			continue
+		}
+		key, _ := row[0].(string)
+		if key == "" {
+			continue
+		}
+
+		index := len(schema.columns)
+		if len(row) > 1 {
+			if n, convErr := strconv.Atoi(fmt.Sprint(row[1])); convErr == nil {
+				index = n
+			}
+		}
+		deprecated := len(row) > 2 && strings.EqualFold(fmt.Sprint(row[2]), "true")
+
+		schema.columns = append(schema.columns, schemaColumn{Key: key, Index: index, Deprecated: deprecated})
+		schema.byKey[key] = index
+	}
+	return schema, nil
+}
+
+// ensureSchemaSheet creates the hidden "__schema" sheet the first time a
+// spreadsheet is seen.
+func (gDrive *GDrive) ensureSchemaSheet(sheet *sheets.Spreadsheet) error {
+	for _, s := range sheet.Sheets {
+		if s.Properties.Title == schemaSheetName {
+			return nil
+		}
+	}
+
+	_, err := gDrive.sheetsSrv.Spreadsheets.BatchUpdate(sheet.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				AddSheet: &sheets.AddSheetRequest{
+					Properties: &sheets.SheetProperties{
+						Title:  schemaSheetName,
+						Hidden: true,
+					},
+				},
+			},
+		},
+	}).Do()
+	if err != nil {
+		return err
+	}
+
+	sheet.Sheets = append(sheet.Sheets, &sheets.Sheet{
+		Properties: &sheets.SheetProperties{Title: schemaSheetName, Hidden: true},
+	})
+	return nil
+}
+
+// appendDataColumn grows the data sheet itself by one column so a newly
+// schema'd key has somewhere to live.
+func (gDrive *GDrive) appendDataColumn(spreadsheetID string, sheetID int64) error {
+	_, err := gDrive.sheetsSrv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{
+			{
+				AppendDimension: &sheets.AppendDimensionRequest{
+					SheetId:   sheetID,
+					Dimension: "COLUMNS",
+					Length:    1,
+				},
+			},
+		},
+	}).Do()
+	return err
+}
+
+// persistSchema overwrites the "__schema" sheet with the current column
+// layout.
+func (gDrive *GDrive) persistSchema(spreadsheetID string, schema *sheetSchema) error {
+	rows := make([][]interface{}, 0, len(schema.columns)+1)
+	rows = append(rows, []interface{}{"key", "index", "deprecated"})
+	for _, col := range schema.columns {
+		rows = append(rows, []interface{}{col.Key, col.Index, col.Deprecated})
+	}
+
+	rng := fmt.Sprintf("%s!A1:C%d", schemaSheetName, len(rows))
+	_, err := gDrive.sheetsSrv.Spreadsheets.Values.Update(spreadsheetID, rng, &sheets.ValueRange{
+		Values: rows,
+	}).
+		ValueInputOption("RAW").
+		Do()
+	return err
+}
+
+// primarySheetID returns the sheet ID of the data sheet, i.e. the first
+// sheet in the spreadsheet that isn't the hidden schema sheet.
+func primarySheetID(sheet *sheets.Spreadsheet) int64 {
+	for _, s := range sheet.Sheets {
+		if s.Properties.Title != schemaSheetName {
+			return s.Properties.SheetId
+		}
+	}
+	return 0
+}
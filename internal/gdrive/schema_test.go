@@ -0,0 +1,81 @@
+package gdrive
+
+import "testing"
+
+// TestSheetSchemaColumnStability simulates three successive LogRow-style
+// calls with different key sets and asserts that a key's column index
+// never changes once assigned, that new keys get new columns, and that
+// keys dropped from later calls are marked deprecated rather than
+// removed.
+func TestSheetSchemaColumnStability(t *testing.T) {
+	schema := &sheetSchema{byKey: make(map[string]int)}
+
+	sync := func(keys ...string) {
+		for _, newKey := range schema.diff(keys) {
+			schema.add(newKey)
+		}
+	}
+
+	// First call: cpu, mem.
+	sync("cpu", "mem")
+	cpuIndex, ok := schema.indexOf("cpu")
+	if !ok {
+		t.Fatalf("expected cpu to have a column after first call")
+	}
+	memIndex, ok := schema.indexOf("mem")
+	if !ok {
+		t.Fatalf("expected mem to have a column after first call")
+	}
+	if cpuIndex == memIndex {
+		t.Fatalf("cpu and mem got the same column index %d", cpuIndex)
+	}
+
+	// Second call: mem drops out, disk is new.
+	sync("disk", "mem")
+	if i, _ := schema.indexOf("cpu"); i != cpuIndex {
+		t.Fatalf("cpu column moved from %d to %d", cpuIndex, i)
+	}
+	if i, _ := schema.indexOf("mem"); i != memIndex {
+		t.Fatalf("mem column moved from %d to %d", memIndex, i)
+	}
+	diskIndex, ok := schema.indexOf("disk")
+	if !ok {
+		t.Fatalf("expected disk to have a column after second call")
+	}
+	if diskIndex == cpuIndex || diskIndex == memIndex {
+		t.Fatalf("disk reused an existing column index %d", diskIndex)
+	}
+	if !columnDeprecated(schema, "cpu") {
+		t.Fatalf("expected cpu to be deprecated after being dropped")
+	}
+	if columnDeprecated(schema, "mem") {
+		t.Fatalf("mem should not be deprecated, it's still present")
+	}
+
+	// Third call: cpu comes back, mem and disk stay.
+	sync("cpu", "mem", "disk")
+	if i, _ := schema.indexOf("cpu"); i != cpuIndex {
+		t.Fatalf("cpu column moved from %d to %d after reappearing", cpuIndex, i)
+	}
+	if i, _ := schema.indexOf("mem"); i != memIndex {
+		t.Fatalf("mem column moved from %d to %d", memIndex, i)
+	}
+	if i, _ := schema.indexOf("disk"); i != diskIndex {
+		t.Fatalf("disk column moved from %d to %d", diskIndex, i)
+	}
+	if columnDeprecated(schema, "cpu") {
+		t.Fatalf("cpu should no longer be deprecated, it reappeared")
+	}
+	if schema.width() != 3 {
+		t.Fatalf("expected 3 columns total, got %d", schema.width())
+	}
+}
+
+func columnDeprecated(schema *sheetSchema, key string) bool {
+	for _, col := range schema.columns {
+		if col.Key == key {
+			return col.Deprecated
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package gdrive
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+const (
+	defaultChunkSize  = 500
+	defaultMaxRetries = 5
+
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+)
+
+// Option configures a GDrive returned by Create.
+type Option func(*GDrive)
+
+// WithChunkSize sets how many rows the Uploader batches together before
+// issuing a Values.Append call. Defaults to 500.
+func WithChunkSize(rows int) Option {
+	return func(g *GDrive) {
+		g.chunkSize = rows
+	}
+}
+
+// WithMaxRetries sets how many times a failed append is retried with
+// exponential backoff before giving up. Defaults to 5.
+func WithMaxRetries(n int) Option {
+	return func(g *GDrive) {
+		g.maxRetries = n
+	}
+}
+
+// Uploader batches the rows passed to LogRow per sheet title and flushes
+// them in chunks of gDrive.chunkSize, retrying transient failures
+// (HTTP 429 and 5xx) with exponential backoff.
+type Uploader struct {
+	gDrive *GDrive
+
+	mu      sync.Mutex
+	pending map[string][][]interface{}
+
+	// spreadsheetIDs remembers which spreadsheet each title was last
+	// resolved to, so Flush can send buffered rows without depending on
+	// a sheet having already been looked up in this call.
+	spreadsheetIDs map[string]string
+}
+
+func newUploader(gDrive *GDrive) *Uploader {
+	return &Uploader{
+		gDrive:         gDrive,
+		pending:        make(map[string][][]interface{}),
+		spreadsheetIDs: make(map[string]string),
+	}
+}
+
+// Add expands row into individual sheet rows, buffers them under title,
+// and flushes whenever the buffer reaches gDrive.chunkSize.
+//
+// Chunks ready to flush are snapshotted out of pending while u.mu is
+// held, then sent via flushChunk after it's released: flushChunk makes
+// the actual Sheets API call and retries with exponential backoff, and
+// u.mu is shared by every title, so holding it across that I/O would
+// stall LogRow for every other title for the full retry duration.
+func (u *Uploader) Add(title, description string, row map[string][]interface{}) error {
+	sheet, err := u.gDrive.getSheet(title, description, row)
+	if err != nil {
+		return err
+	}
+
+	schema, err := u.gDrive.schemaFor(sheet)
+	if err != nil {
+		return err
+	}
+
+	// schema is a pointer shared with every other concurrent caller of
+	// LogRow (it's cached on gDrive, not copied), and syncSchema mutates
+	// it in place under gDrive.mu, so reading it here needs the same
+	// lock.
+	gDrive := u.gDrive
+	gDrive.mu.Lock()
+	values := valuesForSchema(schema, row)
+	gDrive.mu.Unlock()
+
+	u.mu.Lock()
+	u.spreadsheetIDs[title] = sheet.SpreadsheetId
+	u.pending[title] = append(u.pending[title], values...)
+
+	var chunks [][][]interface{}
+	for len(u.pending[title]) >= u.gDrive.chunkSize {
+		chunks = append(chunks, u.pending[title][:u.gDrive.chunkSize])
+		u.pending[title] = u.pending[title][u.gDrive.chunkSize:]
+	}
+	u.mu.Unlock()
+
+	for _, chunk := range chunks {
+		if err := u.flushChunk(sheet.SpreadsheetId, title, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush sends any rows still buffered for title, regardless of chunk
+// size. Callers should call Flush for every title before shutting down
+// to avoid losing buffered rows.
+//
+// As in Add, the buffered rows are taken out of pending while u.mu is
+// held, then sent via flushChunk after it's released.
+func (u *Uploader) Flush(title string) error {
+	u.mu.Lock()
+	rows := u.pending[title]
+	spreadsheetID, ok := u.spreadsheetIDs[title]
+	if len(rows) == 0 || !ok {
+		u.mu.Unlock()
+		return nil
+	}
+	delete(u.pending, title)
+	u.mu.Unlock()
+
+	return u.flushChunk(spreadsheetID, title, rows)
+}
+
+// FlushAll flushes every title with buffered rows.
+func (u *Uploader) FlushAll() error {
+	u.mu.Lock()
+	titles := make([]string, 0, len(u.pending))
+	for title := range u.pending {
+		titles = append(titles, title)
+	}
+	u.mu.Unlock()
+
+	for _, title := range titles {
+		if err := u.Flush(title); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *Uploader) flushChunk(spreadsheetID, title string, rows [][]interface{}) error {
+	return withRetry(u.gDrive.maxRetries, func() error {
+		_, err := u.gDrive.sheetsSrv.Spreadsheets.Values.Append(spreadsheetID, title, &sheets.ValueRange{
+			Values: rows,
+		}).
+			ValueInputOption("RAW").
+			InsertDataOption("INSERT_ROWS").
+			Do()
+		return err
+	})
+}
+
+// withRetry calls fn, retrying on transient googleapi errors (429 and
+// 5xx) with exponential backoff, honouring Retry-After when the server
+// sends one.
+func withRetry(maxRetries int, fn func() error) error {
+	backoff := initialBackoff
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		apiErr, ok := err.(*googleapi.Error)
+		if !ok || !isRetryableCode(apiErr.Code) || attempt == maxRetries {
+			return err
+		}
+
+		wait := backoff
+		if retryAfter := retryAfterFromHeader(apiErr); retryAfter > 0 {
+			wait = retryAfter
+		}
+		time.Sleep(wait)
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return err
+}
+
+func isRetryableCode(code int) bool {
+	switch code {
+	case 429, 500, 502, 503, 504:
+		return true
+	default:
+		return false
+	}
+}
+
+func retryAfterFromHeader(err *googleapi.Error) time.Duration {
+	if err.Header == nil {
+		return 0
+	}
+	seconds, parseErr := strconv.Atoi(err.Header.Get("Retry-After"))
+	if parseErr != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -3,52 +3,17 @@ package gdrive
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"math"
-	"net/http"
 	"os"
 	"strconv"
+	"sync"
 
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/sheets/v4"
 )
 
-// Retrieve a token, saves the token, then returns the generated client.
-func getClient(config *oauth2.Config) *http.Client {
-	// The file token.json stores the user's access and refresh tokens, and is
-	// created automatically when the authorization flow completes for the first
-	// time.
-	tokFile := "token.json"
-	tok, err := tokenFromFile(tokFile)
-	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(tokFile, tok)
-	}
-	return config.Client(context.Background(), tok)
-}
-
-// Request a token from the web, then returns the retrieved token.
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
-
-	var authCode string
-	if _, err := fmt.Scan(&authCode); err != nil {
-		log.Fatalf("Unable to read authorization code: %v", err)
-	}
-
-	tok, err := config.Exchange(context.TODO(), authCode)
-	if err != nil {
-		log.Fatalf("Unable to retrieve token from web: %v", err)
-	}
-	return tok
-}
-
 // Retrieves a token from a local file.
 func tokenFromFile(file string) (*oauth2.Token, error) {
 	f, err := os.Open(file)
@@ -62,14 +27,14 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 }
 
 // Saves a token to a file path.
-func saveToken(path string, token *oauth2.Token) {
+func saveToken(path string, token *oauth2.Token) error {
 	fmt.Printf("Saving credential file to: %s\n", path)
 	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+		return err
 	}
 	defer f.Close()
-	json.NewEncoder(f).Encode(token)
+	return json.NewEncoder(f).Encode(token)
 }
 
 // GDrive will hold services related to google drive services
@@ -80,157 +45,231 @@ type GDrive struct {
 	// ID of base folder
 	folderID string
 
+	// mu guards sheets, schemas and the lazily-created uploader below,
+	// since LogRow may be called concurrently (e.g. from goroutines
+	// iterating resource kinds).
+	mu sync.Mutex
+
 	// Maps each thing we want a sheet for to a sheet
 	sheets map[string]*sheets.Spreadsheet
+
+	// chunkSize and maxRetries configure the Uploader used by LogRow, see
+	// WithChunkSize and WithMaxRetries.
+	chunkSize  int
+	maxRetries int
+	uploader   *Uploader
+
+	// driveID is the Shared Drive ID requests are scoped to, empty for
+	// the user's own "My Drive".
+	driveID     string
+	folderCache *folderCache
+
+	// schemas caches each spreadsheet's stable column layout, keyed by
+	// spreadsheet ID. See schema.go.
+	schemas map[string]*sheetSchema
+}
+
+// filesList starts a Files.List call scoped to gDrive.driveID, if one is
+// configured, so every lookup in this package works against Shared
+// Drives as well as "My Drive".
+func (gDrive *GDrive) filesList() *drive.FilesListCall {
+	call := gDrive.driveSrv.Files.List().
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true)
+	if gDrive.driveID != "" {
+		call = call.Corpora("drive").DriveId(gDrive.driveID)
+	}
+	return call
 }
 
-func (gDrive *GDrive) updateSheetHead(fileID, title, description string, data map[string][]interface{}) error {
-	keys := make([]interface{}, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
+// updateSheetHead writes the description and header row for a sheet,
+// with column labels positioned by schema index so they stay aligned
+// with the data rows LogRow appends underneath.
+func (gDrive *GDrive) updateSheetHead(fileID, description string, schema *sheetSchema) error {
+	width := schema.width()
+	if width == 0 {
+		width = 1
 	}
-	values := [][]interface{}{{description}, keys}
+
+	headers := make([]interface{}, width)
+	for _, col := range schema.columns {
+		label := col.Key
+		if col.Deprecated {
+			label += " (deprecated)"
+		}
+		headers[col.Index] = label
+	}
+
+	values := [][]interface{}{{description}, headers}
 	valueRange := &sheets.ValueRange{
 		Values: values,
 	}
 	_, err := gDrive.sheetsSrv.Spreadsheets.Values.Update(
 		fileID,
-		"A1:"+columnToLetter(1+len(keys))+strconv.Itoa(len(values)),
+		"A1:"+columnToLetter(width)+strconv.Itoa(len(values)),
 		valueRange,
 	).
 		ValueInputOption("RAW").
 		Do()
-	if err != nil {
-		return err
-	}
-	return nil
+	return err
 }
 
+// getSheet returns the spreadsheet backing title, resolving and caching
+// it via Files.List/Spreadsheets.Get on the first call. Later calls hit
+// the cache, so the only per-Add API traffic is the schema diff (and,
+// when it actually changes something, a header rewrite).
 func (gDrive *GDrive) getSheet(title string, description string, data map[string][]interface{}) (*sheets.Spreadsheet, error) {
-	var sheet *sheets.Spreadsheet
-	sheet, exists := gDrive.sheets[title]
-	if !exists {
-		files, err := gDrive.driveSrv.Files.List().
-			Q(
-				fmt.Sprintf("name = '%s'", title) +
-					" and" +
-					fmt.Sprintf("'%s' in parents", gDrive.folderID),
-			).
+	if sheet, exists := gDrive.cachedSheet(title); exists {
+		if err := gDrive.syncSheetHead(sheet, description, data); err != nil {
+			return nil, err
+		}
+		return sheet, nil
+	}
+
+	files, err := gDrive.filesList().
+		Q(
+			fmt.Sprintf("name = '%s'", title) +
+				" and" +
+				fmt.Sprintf("'%s' in parents", gDrive.folderID),
+		).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var fileID string
+	switch len(files.Files) {
+	case 0:
+		file, err := gDrive.driveSrv.Files.Create(&drive.File{
+			Name:     title,
+			MimeType: "application/vnd.google-apps.spreadsheet",
+			Parents:  []string{gDrive.folderID},
+		}).
+			SupportsAllDrives(true).
 			Do()
 		if err != nil {
 			return nil, err
 		}
-		/*
-			for _, file := range files.Files {
-				err := gDrive.driveSrv.Files.Delete(file.Id).Do()
-				if err != nil {
-					return nil, err
-				}
-			}
-			return nil, nil
-		*/
-		if len(files.Files) == 0 {
-			file, err := gDrive.driveSrv.Files.Create(&drive.File{
-				Name:     title,
-				MimeType: "application/vnd.google-apps.spreadsheet",
-				Parents:  []string{gDrive.folderID},
-			}).Do()
-			if err != nil {
-				return nil, err
-			}
-			err = gDrive.updateSheetHead(file.Id, title, description, data)
-			if err != nil {
-				return nil, err
-			}
-
-			sheet, err = gDrive.sheetsSrv.Spreadsheets.Get(file.Id).Do()
-			if err != nil {
-				return nil, err
-			}
-
-		} else if len(files.Files) == 1 {
-			err = gDrive.updateSheetHead(files.Files[0].Id, title, description, data)
-			if err != nil {
-				return nil, err
-			}
-			sheet, err = gDrive.sheetsSrv.Spreadsheets.Get(files.Files[0].Id).Do()
-		} else {
-			return nil, fmt.Errorf("Got %d files with name %s, expected 1", len(files.Files), title)
-		}
+		fileID = file.Id
+	case 1:
+		fileID = files.Files[0].Id
+	default:
+		return nil, fmt.Errorf("Got %d files with name %s, expected 1", len(files.Files), title)
+	}
+
+	sheet, err := gDrive.sheetsSrv.Spreadsheets.Get(fileID).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gDrive.syncSheetHead(sheet, description, data); err != nil {
+		return nil, err
 	}
 
+	gDrive.cacheSheet(title, sheet)
 	return sheet, nil
 }
 
-func (gDrive GDrive) LogRow(title string, description string, row map[string][]interface{}) error {
-	_, err := gDrive.getSheet(title, description, row)
+// syncSheetHead diffs data's keys against the sheet's stored schema and
+// only rewrites the header row when that diff actually changed a column
+// (added, deprecated or un-deprecated), so a steady-state key set costs
+// no Values.Update calls beyond the very first one.
+func (gDrive *GDrive) syncSheetHead(sheet *sheets.Spreadsheet, description string, data map[string][]interface{}) error {
+	schema, changed, err := gDrive.syncSchema(sheet, data)
 	if err != nil {
 		return err
 	}
-	/*
-		_, err = gDrive.sheetsSrv.Spreadsheets.Get(sheet.SpreadsheetId).Do()
-		if err != nil {
-			return err
-		}
-	*/
-	return nil
+	if !changed {
+		return nil
+	}
+	return gDrive.updateSheetHead(sheet.SpreadsheetId, description, schema)
 }
 
-// Create sets up connections and credentials
-func Create() GDrive {
-	b, err := ioutil.ReadFile("credentials.json")
-	if err != nil {
-		log.Fatalf("Unable to read client secret file: %v", err)
+// cachedSheet returns the spreadsheet already resolved for title, if any.
+func (gDrive *GDrive) cachedSheet(title string) (*sheets.Spreadsheet, bool) {
+	gDrive.mu.Lock()
+	defer gDrive.mu.Unlock()
+	sheet, exists := gDrive.sheets[title]
+	return sheet, exists
+}
+
+// cacheSheet remembers sheet as the resolved spreadsheet for title.
+func (gDrive *GDrive) cacheSheet(title string, sheet *sheets.Spreadsheet) {
+	gDrive.mu.Lock()
+	defer gDrive.mu.Unlock()
+	if gDrive.sheets == nil {
+		gDrive.sheets = make(map[string]*sheets.Spreadsheet)
+	}
+	gDrive.sheets[title] = sheet
+}
+
+func (gDrive *GDrive) LogRow(title string, description string, row map[string][]interface{}) error {
+	gDrive.mu.Lock()
+	if gDrive.uploader == nil {
+		gDrive.uploader = newUploader(gDrive)
 	}
+	uploader := gDrive.uploader
+	gDrive.mu.Unlock()
+
+	return uploader.Add(title, description, row)
+}
 
-	// If modifying these scopes, delete your previously saved token.json.
-	config, err := google.ConfigFromJSON(b, "https://www.googleapis.com/auth/drive.file")
+// Close flushes any rows still buffered by the Uploader. It satisfies
+// sinks.Sink so a *GDrive can be used wherever a Sink is expected.
+func (gDrive *GDrive) Close() error {
+	gDrive.mu.Lock()
+	uploader := gDrive.uploader
+	gDrive.mu.Unlock()
+
+	if uploader == nil {
+		return nil
+	}
+	return uploader.FlushAll()
+}
+
+// Create sets up connections and credentials from the given Config. The
+// credential type, token cache path and scopes are all taken from cfg
+// rather than hard-coded, so the exporter can run unattended (service
+// account) or interactively (user OAuth via a local loopback redirect).
+//
+// It returns a *GDrive, not a GDrive, because GDrive carries a
+// sync.Mutex: returning it by value would let go vet catch a lock copy
+// at best, and silently hand callers a copy of the mutex at worst.
+func Create(cfg Config, opts ...Option) (*GDrive, error) {
+	client, err := newClient(&cfg)
 	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+		return nil, err
 	}
-	client := getClient(config)
 
 	driveSrv, err := drive.New(client)
 	if err != nil {
-		log.Fatalf("Unable to retrieve Drive client: %v", err)
+		return nil, fmt.Errorf("unable to retrieve Drive client: %v", err)
 	}
 
-	files, err := driveSrv.Files.List().
-		Q("mimeType = 'application/vnd.google-apps.folder'").
-		Do()
+	sheetsSrv, err := sheets.New(client)
 	if err != nil {
-		log.Fatalf("Unable to retrieve files: %v", err)
+		return nil, fmt.Errorf("unable to retrieve Sheets client: %v", err)
 	}
 
-	var folder *drive.File
-	for _, file := range files.Files {
-		if file.Name == "k8sheets" {
-			folder = file
-		}
+	gDrive := &GDrive{
+		driveSrv:    driveSrv,
+		sheetsSrv:   sheetsSrv,
+		chunkSize:   defaultChunkSize,
+		maxRetries:  defaultMaxRetries,
+		driveID:     cfg.DriveId,
+		folderCache: newFolderCache(cfg.folderCacheSize(), cfg.folderCacheTTL()),
 	}
-	if folder == nil {
-		folder, err = driveSrv.Files.Create(&drive.File{
-			Name:     "k8sheets",
-			MimeType: "application/vnd.google-apps.folder",
-		}).
-			Do()
-		if err != nil {
-			log.Fatalf("Unable to create folder: %v", err)
-		}
+	for _, opt := range opts {
+		opt(gDrive)
 	}
 
-	sheetsSrv, err := sheets.New(client)
+	folderID, err := gDrive.ResolvePath(context.Background(), cfg.folderPath())
 	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets client: %v", err)
-	}
-
-	gDrive := GDrive{
-		driveSrv:  driveSrv,
-		sheetsSrv: sheetsSrv,
-		folderID:  folder.Id,
+		return nil, fmt.Errorf("unable to resolve folder path %q: %v", cfg.folderPath(), err)
 	}
+	gDrive.folderID = folderID
 
-	return gDrive
+	return gDrive, nil
 }
 
 // https://stackoverflow.com/a/21231012
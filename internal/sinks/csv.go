@@ -0,0 +1,111 @@
+package sinks
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// CSVSink writes each row to a CSV file rotated per sheet title, named
+// "<dir>/<title>.csv". The header is derived from the first LogRow call
+// for a title and written once; later calls append records in that
+// order.
+type CSVSink struct {
+	dir string
+
+	mu    sync.Mutex
+	files map[string]*csvFile
+}
+
+type csvFile struct {
+	f      *os.File
+	w      *csv.Writer
+	header []string
+}
+
+// NewCSVSink returns a Sink that rotates a CSV file per title inside
+// dir, creating dir if it doesn't already exist.
+func NewCSVSink(dir string) (*CSVSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create csv sink directory: %v", err)
+	}
+	return &CSVSink{dir: dir, files: make(map[string]*csvFile)}, nil
+}
+
+// LogRow implements Sink.
+func (s *CSVSink) LogRow(title, description string, row map[string][]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.fileFor(title, row)
+	if err != nil {
+		return err
+	}
+	warnDroppedKeys(title, file.header, row)
+
+	for _, record := range stringRowsFromColumns(file.header, row) {
+		if err := file.w.Write(record); err != nil {
+			return err
+		}
+	}
+	file.w.Flush()
+	return file.w.Error()
+}
+
+func (s *CSVSink) fileFor(title string, row map[string][]interface{}) (*csvFile, error) {
+	if file, ok := s.files[title]; ok {
+		return file, nil
+	}
+
+	header := make([]string, 0, len(row))
+	for k := range row {
+		header = append(header, k)
+	}
+	sort.Strings(header)
+
+	f, err := os.OpenFile(filepath.Join(s.dir, title+".csv"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open csv file for %s: %v", title, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	w := csv.NewWriter(f)
+	if info.Size() == 0 {
+		if err := w.Write(header); err != nil {
+			f.Close()
+			return nil, err
+		}
+		w.Flush()
+	}
+
+	file := &csvFile{f: f, w: w, header: header}
+	s.files[title] = file
+	return file, nil
+}
+
+// Close flushes and closes every file this sink has opened.
+func (s *CSVSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for title, file := range s.files {
+		file.w.Flush()
+		if err := file.w.Error(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := file.f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(s.files, title)
+	}
+	return firstErr
+}
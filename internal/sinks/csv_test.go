@@ -0,0 +1,95 @@
+package sinks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCSVSinkWritesRowsUnderFrozenHeader(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewCSVSink(dir)
+	if err != nil {
+		t.Fatalf("NewCSVSink: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.LogRow("pods", "pod metrics", map[string][]interface{}{
+		"cpu": {1},
+		"mem": {2},
+	}); err != nil {
+		t.Fatalf("LogRow: %v", err)
+	}
+
+	// A later call introducing a new key must not change the header
+	// established by the first call.
+	if err := s.LogRow("pods", "pod metrics", map[string][]interface{}{
+		"cpu":  {3},
+		"mem":  {4},
+		"disk": {5},
+	}); err != nil {
+		t.Fatalf("LogRow: %v", err)
+	}
+	s.Close()
+
+	records := readCSV(t, filepath.Join(dir, "pods.csv"))
+	want := [][]string{
+		{"cpu", "mem"},
+		{"1", "2"},
+		{"3", "4"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d records, got %d: %v", len(want), len(records), records)
+	}
+	for i, record := range want {
+		if strings.Join(records[i], ",") != strings.Join(record, ",") {
+			t.Fatalf("record %d: expected %v, got %v", i, record, records[i])
+		}
+	}
+}
+
+func TestCSVSinkWarnsOnDroppedKey(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	dir := t.TempDir()
+	s, err := NewCSVSink(dir)
+	if err != nil {
+		t.Fatalf("NewCSVSink: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.LogRow("pods", "pod metrics", map[string][]interface{}{"cpu": {1}}); err != nil {
+		t.Fatalf("LogRow: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected no warning on the first call, got %q", buf.String())
+	}
+
+	if err := s.LogRow("pods", "pod metrics", map[string][]interface{}{"cpu": {2}, "disk": {3}}); err != nil {
+		t.Fatalf("LogRow: %v", err)
+	}
+	if !strings.Contains(buf.String(), "disk") {
+		t.Fatalf("expected a warning mentioning the dropped key %q, got %q", "disk", buf.String())
+	}
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return records
+}
@@ -0,0 +1,38 @@
+package sinks
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/kube-state-metrics/internal/gdrive"
+)
+
+func newGDriveSink(cfg map[string]string) (Sink, error) {
+	if cfg["credentials_file"] == "" {
+		return nil, fmt.Errorf("gdrive sink requires credentials_file")
+	}
+
+	credType := gdrive.CredentialTypeServiceAccount
+	if cfg["credential_type"] == "user_oauth" {
+		credType = gdrive.CredentialTypeUserOAuth
+	}
+
+	gCfg := gdrive.Config{
+		CredentialType:  credType,
+		CredentialsFile: cfg["credentials_file"],
+		FolderName:      cfg["folder"],
+		FolderPath:      cfg["folder_path"],
+		DriveId:         cfg["drive_id"],
+	}
+
+	var opts []gdrive.Option
+	if n, err := strconv.Atoi(cfg["chunk_size"]); err == nil && n > 0 {
+		opts = append(opts, gdrive.WithChunkSize(n))
+	}
+
+	g, err := gdrive.Create(gCfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
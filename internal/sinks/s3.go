@@ -0,0 +1,93 @@
+package sinks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3Sink uploads each LogRow call as a CSV object to an S3 bucket,
+// keyed by "<prefix><title>/<unix-nano>.csv", mirroring the
+// storage-provider pattern transfer.sh uses for its own upload backends.
+type S3Sink struct {
+	bucket string
+	prefix string
+	client *s3.S3
+
+	mu      sync.Mutex
+	headers map[string][]string
+}
+
+// NewS3Sink returns a Sink backed by the given S3 bucket. region
+// selects the AWS region; prefix, which may be empty, is prepended to
+// every object key.
+func NewS3Sink(bucket, region, prefix string) (*S3Sink, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create S3 session: %v", err)
+	}
+	return &S3Sink{
+		bucket:  bucket,
+		prefix:  prefix,
+		client:  s3.New(sess),
+		headers: make(map[string][]string),
+	}, nil
+}
+
+// LogRow implements Sink.
+func (s *S3Sink) LogRow(title, description string, row map[string][]interface{}) error {
+	header := s.headerFor(title, row)
+	warnDroppedKeys(title, header, row)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, record := range stringRowsFromColumns(header, row) {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s%s/%d.csv", s.prefix, title, time.Now().UnixNano())
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(buf.Bytes()),
+	})
+	return err
+}
+
+func (s *S3Sink) headerFor(title string, row map[string][]interface{}) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if header, ok := s.headers[title]; ok {
+		return header
+	}
+
+	header := make([]string, 0, len(row))
+	for k := range row {
+		header = append(header, k)
+	}
+	sort.Strings(header)
+	s.headers[title] = header
+	return header
+}
+
+// Close is a no-op: S3Sink holds no local resources to release.
+func (s *S3Sink) Close() error {
+	return nil
+}
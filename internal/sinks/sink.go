@@ -0,0 +1,46 @@
+// Package sinks decouples kube-state-metrics' log exporter from any one
+// storage backend. gdrive.GDrive already satisfies Sink; CSVSink and
+// S3Sink are alternatives for operators who don't want to run the
+// drive.file OAuth flow.
+package sinks
+
+import "fmt"
+
+// Sink is the destination LogRow results are written to.
+type Sink interface {
+	LogRow(title, description string, row map[string][]interface{}) error
+	Close() error
+}
+
+// New builds a Sink by name from cfg, whose keys are usually sourced
+// from CLI flags or environment variables. Flags.AddFlags/Flags.Sink in
+// this package register the --sink flag and build cfg from it; wiring
+// that into kube-state-metrics' actual entrypoint is left to whichever
+// main package registers flags for the exporter, which isn't part of
+// this tree.
+//
+// Supported names and their cfg keys:
+//
+//	"gdrive" - credentials_file, credential_type (service_account|user_oauth), folder, folder_path, drive_id, chunk_size
+//	"csv"    - dir
+//	"s3"     - bucket, region, prefix
+func New(name string, cfg map[string]string) (Sink, error) {
+	switch name {
+	case "gdrive":
+		return newGDriveSink(cfg)
+	case "csv":
+		dir := cfg["dir"]
+		if dir == "" {
+			dir = "."
+		}
+		return NewCSVSink(dir)
+	case "s3":
+		bucket := cfg["bucket"]
+		if bucket == "" {
+			return nil, fmt.Errorf("s3 sink requires a bucket")
+		}
+		return NewS3Sink(bucket, cfg["region"], cfg["prefix"])
+	default:
+		return nil, fmt.Errorf("unknown sink %q", name)
+	}
+}
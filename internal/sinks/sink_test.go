@@ -0,0 +1,41 @@
+package sinks
+
+import "testing"
+
+func TestNewDispatchesByName(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		sink    string
+		cfg     map[string]string
+		wantErr bool
+	}{
+		{name: "csv", sink: "csv", cfg: map[string]string{"dir": dir}},
+		{name: "s3", sink: "s3", cfg: map[string]string{"bucket": "my-bucket", "region": "us-east-1"}},
+		{name: "s3 without bucket", sink: "s3", cfg: map[string]string{}, wantErr: true},
+		{name: "gdrive without credentials_file", sink: "gdrive", cfg: map[string]string{}, wantErr: true},
+		{name: "unknown sink", sink: "carrier-pigeon", cfg: map[string]string{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.sink, tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New(%q): %v", tt.sink, err)
+			}
+			if s == nil {
+				t.Fatalf("expected a non-nil Sink")
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package sinks
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// warnDroppedKeys logs a warning for any key in row that isn't part of
+// header. CSVSink and S3Sink fix their header from the first LogRow call
+// for a title, so a later call introducing a new key would otherwise
+// have that key's values silently dropped.
+func warnDroppedKeys(title string, header []string, row map[string][]interface{}) {
+	known := make(map[string]bool, len(header))
+	for _, k := range header {
+		known[k] = true
+	}
+
+	var dropped []string
+	for k := range row {
+		if !known[k] {
+			dropped = append(dropped, k)
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+	sort.Strings(dropped)
+	log.Printf("sinks: title %q has keys %v not present in its header, established on the first LogRow call; dropping their values", title, dropped)
+}
+
+// stringRowsFromColumns converts the column-major {key: samples} shape
+// LogRow takes into row-major [][]string records ordered by header,
+// stringifying each cell for CSV output.
+func stringRowsFromColumns(header []string, data map[string][]interface{}) [][]string {
+	n := 0
+	for _, k := range header {
+		if len(data[k]) > n {
+			n = len(data[k])
+		}
+	}
+
+	records := make([][]string, n)
+	for i := 0; i < n; i++ {
+		record := make([]string, len(header))
+		for j, k := range header {
+			col := data[k]
+			if i < len(col) {
+				record[j] = fmt.Sprintf("%v", col[i])
+			}
+		}
+		records[i] = record
+	}
+	return records
+}